@@ -0,0 +1,97 @@
+package tcpserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxConnectionsLimitsConcurrentHandlers(t *testing.T) {
+	var current, max int32
+	srv := New(
+		Address("127.0.0.1:0"),
+		MaxConnections(1),
+		TCPHandler(func(ctx context.Context, con net.Conn) error {
+			defer con.Close()
+			n := atomic.AddInt32(&current, 1)
+			for {
+				old := atomic.LoadInt32(&max)
+				if n <= old || atomic.CompareAndSwapInt32(&max, old, n) {
+					break
+				}
+			}
+			time.Sleep(100 * time.Millisecond)
+			atomic.AddInt32(&current, -1)
+			return nil
+		}),
+	)
+	go srv.Serve(context.Background())
+	<-srv.Serving()
+
+	addr := srv.listener.Addr().String()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := net.Dial("tcp", addr)
+			if err != nil {
+				return
+			}
+			defer c.Close()
+			time.Sleep(200 * time.Millisecond)
+		}()
+	}
+	wg.Wait()
+	time.Sleep(450 * time.Millisecond) // let the serialized handlers finish
+
+	if got := atomic.LoadInt32(&max); got > 1 {
+		t.Fatalf("observed %d concurrent handlers, want <= 1 with MaxConnections(1)", got)
+	}
+}
+
+func TestOnAcceptAndOnHandlerErrorCallbacks(t *testing.T) {
+	accepted := make(chan net.Addr, 1)
+	handlerErrs := make(chan error, 1)
+	boom := errors.New("boom")
+
+	srv := New(
+		Address("127.0.0.1:0"),
+		OnAccept(func(con net.Conn) {
+			accepted <- con.RemoteAddr()
+		}),
+		OnHandlerError(func(con net.Conn, err error) {
+			handlerErrs <- err
+		}),
+		TCPHandler(func(ctx context.Context, con net.Conn) error {
+			return boom
+		}),
+	)
+	go srv.Serve(context.Background())
+	<-srv.Serving()
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	select {
+	case <-accepted:
+	case <-time.After(time.Second):
+		t.Fatalf("OnAccept callback was not invoked")
+	}
+
+	select {
+	case err := <-handlerErrs:
+		if !errors.Is(err, boom) {
+			t.Fatalf("OnHandlerError() err = %v, want %v", err, boom)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("OnHandlerError callback was not invoked")
+	}
+}
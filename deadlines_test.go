@@ -0,0 +1,30 @@
+package tcpserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestApplyConnDeadlinesIdleDoesNotClobberReadTimeout(t *testing.T) {
+	srv := New(
+		ReadTimeout(50*time.Millisecond),
+		IdleTimeout(5*time.Second),
+		TCPHandler(func(ctx context.Context, con net.Conn) error { return nil }),
+	)
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	srv.applyConnDeadlines(server)
+
+	start := time.Now()
+	buf := make([]byte, 1)
+	if _, err := server.Read(buf); err == nil {
+		t.Fatalf("expected Read to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("ReadTimeout was clobbered by the longer IdleTimeout: read blocked for %v", elapsed)
+	}
+}
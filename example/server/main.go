@@ -7,6 +7,7 @@ import (
 	"net/textproto"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/x-mod/glog"
 	"github.com/x-mod/routine"
@@ -25,6 +26,7 @@ func main() {
 		tcpserver.Address("127.0.0.1:8080"),
 		tcpserver.TCPHandler(EchoHandler),
 		tcpserver.NetTrace(true),
+		tcpserver.GracefulRestart(true),
 		// tcpserver.TLSConfig(tlsconfig.New(
 		// 	tlsconfig.CertKeyPair("out/server.crt", "out/server.key"),
 		// )),
@@ -44,7 +46,14 @@ func main() {
 		routine.Go(routine.Profiling("127.0.0.1:6060")),
 		routine.Signal(syscall.SIGINT, routine.SigHandler(func() {
 			cancel()
-			<-srv.Close()
+			<-srv.Close(5 * time.Second)
+		})),
+		routine.Signal(syscall.SIGHUP, routine.SigHandler(func() {
+			if err := srv.Restart(10*time.Second, 5*time.Second); err != nil {
+				log.Println("graceful restart failed:", err)
+				return
+			}
+			cancel()
 		})),
 	); err != nil {
 		log.Println("tcpserver failed:", err)
@@ -0,0 +1,46 @@
+package tcpserver
+
+import (
+	"log/slog"
+	"os"
+)
+
+// Logger is the structured logging interface tcpserver depends on.
+type Logger interface {
+	Debug(msg string, kv ...interface{})
+	Info(msg string, kv ...interface{})
+	Warn(msg string, kv ...interface{})
+	Error(msg string, kv ...interface{})
+}
+
+// slogLogger adapts a *slog.Logger to Logger.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger adapts logger to a tcpserver.Logger.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{l: logger}
+}
+
+func (s *slogLogger) Debug(msg string, kv ...interface{}) { s.l.Debug(msg, kv...) }
+func (s *slogLogger) Info(msg string, kv ...interface{})  { s.l.Info(msg, kv...) }
+func (s *slogLogger) Warn(msg string, kv ...interface{})  { s.l.Warn(msg, kv...) }
+func (s *slogLogger) Error(msg string, kv ...interface{}) { s.l.Error(msg, kv...) }
+
+// defaultLogger writes structured JSON to stderr.
+func defaultLogger() Logger {
+	return NewSlogLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+}
+
+// WithLogger option replaces the server's Logger.
+func WithLogger(l Logger) ServerOpt {
+	return func(srv *Server) {
+		if l != nil {
+			srv.logger = l
+		}
+	}
+}
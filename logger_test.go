@@ -0,0 +1,51 @@
+package tcpserver
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestSlogLoggerLevels(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	l.Info("hello", "k", "v")
+	l.Error("bye", "err", "boom")
+
+	out := buf.String()
+	if !strings.Contains(out, "hello") || !strings.Contains(out, `"k":"v"`) {
+		t.Fatalf("Info() fields missing from output: %s", out)
+	}
+	if !strings.Contains(out, "bye") {
+		t.Fatalf("Error() message missing from output: %s", out)
+	}
+}
+
+func TestWithLoggerOption(t *testing.T) {
+	var buf bytes.Buffer
+	custom := NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	srv := New(
+		WithLogger(custom),
+		TCPHandler(func(ctx context.Context, con net.Conn) error { return nil }),
+	)
+	srv.logInfo("ping")
+
+	if !strings.Contains(buf.String(), "ping") {
+		t.Fatalf("WithLogger() was not wired into srv.logInfo, got: %s", buf.String())
+	}
+}
+
+func TestWithLoggerNilKeepsDefault(t *testing.T) {
+	srv := New(
+		WithLogger(nil),
+		TCPHandler(func(ctx context.Context, con net.Conn) error { return nil }),
+	)
+	if srv.logger == nil {
+		t.Fatalf("WithLogger(nil) cleared the default logger")
+	}
+}
@@ -0,0 +1,145 @@
+package tcpserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ListenerFDEnv names the fd a restarted child reconstructs its listener from
+const ListenerFDEnv = "TCPSERVER_LISTENER_FD"
+
+// ReadyFDEnv names the fd a restarted child signals readiness on
+const ReadyFDEnv = "TCPSERVER_READY_FD"
+
+// defaultRestartReadyTimeout bounds the readiness wait in Restart when the
+// caller passes readyTimeout <= 0.
+const defaultRestartReadyTimeout = 30 * time.Second
+
+// GracefulRestart option enables zero-downtime binary upgrades: the caller
+// wires SIGHUP/SIGUSR2 (see routine.Signal in example/server/main.go) to
+// call Restart, which forks a replacement and hands it the listener fd.
+func GracefulRestart(flag bool) ServerOpt {
+	return func(srv *Server) {
+		srv.gracefulRestart = flag
+	}
+}
+
+// filer is implemented by *net.TCPListener and *net.UnixListener
+type filer interface {
+	File() (*os.File, error)
+}
+
+// inheritedListenerFD returns the fd to reconstruct a listener from, if any
+func inheritedListenerFD(srv *Server) (int, bool) {
+	if !srv.gracefulRestart {
+		return 0, false
+	}
+	v := os.Getenv(ListenerFDEnv)
+	if v == "" {
+		return 0, false
+	}
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return fd, true
+}
+
+// signalReady notifies a parent process, if any, that this process is ready
+func (srv *Server) signalReady() {
+	if !srv.gracefulRestart {
+		return
+	}
+	v := os.Getenv(ReadyFDEnv)
+	if v == "" {
+		return
+	}
+	fd, err := strconv.Atoi(v)
+	if err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), "tcpserver-ready")
+	if f == nil {
+		return
+	}
+	defer f.Close()
+	f.Write([]byte{1})
+}
+
+// waitReady blocks until r yields a readiness byte or timeout elapses,
+// whichever comes first; timeout <= 0 falls back to defaultRestartReadyTimeout.
+func waitReady(r *os.File, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultRestartReadyTimeout
+	}
+	done := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := r.Read(buf)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("no readiness signal within %s", timeout)
+	}
+}
+
+// Restart forks a copy of the running binary, hands it the listener fd, waits
+// for the child to signal readiness, then drains srv (bounded by drainTimeout,
+// <=0 waits indefinitely) and returns; the caller should then exit.
+// Typically called from a caller-installed SIGHUP/SIGUSR2 handler. If the
+// child never signals readiness within readyTimeout (<=0 means
+// defaultRestartReadyTimeout), srv keeps serving and Restart returns an error.
+func (srv *Server) Restart(readyTimeout, drainTimeout time.Duration) error {
+	if !srv.gracefulRestart {
+		return fmt.Errorf("tcpserver: GracefulRestart not enabled")
+	}
+	fl, ok := srv.rawListener.(filer)
+	if !ok {
+		return fmt.Errorf("tcpserver: listener %T does not support fd inheritance", srv.rawListener)
+	}
+	lf, err := fl.File()
+	if err != nil {
+		return err
+	}
+	defer lf.Close()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+
+	env := append(os.Environ(),
+		fmt.Sprintf("%s=3", ListenerFDEnv),
+		fmt.Sprintf("%s=4", ReadyFDEnv),
+	)
+	proc, err := os.StartProcess(os.Args[0], os.Args, &os.ProcAttr{
+		Env:   env,
+		Files: []*os.File{os.Stdin, os.Stdout, os.Stderr, lf, readyW},
+	})
+	readyW.Close()
+	if err != nil {
+		return err
+	}
+	srv.logInfo("forked for graceful restart", "name", srv.name, "pid", proc.Pid)
+
+	if err := waitReady(readyR, readyTimeout); err != nil {
+		return fmt.Errorf("tcpserver: child pid %d did not signal readiness: %w", proc.Pid, err)
+	}
+
+	srv.restarting.Store(true)
+
+	ctx := context.Background()
+	if drainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, drainTimeout)
+		defer cancel()
+	}
+	return srv.Shutdown(ctx)
+}
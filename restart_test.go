@@ -0,0 +1,73 @@
+package tcpserver
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWaitReadyTimesOut(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	start := time.Now()
+	if err := waitReady(r, 50*time.Millisecond); err == nil {
+		t.Fatalf("waitReady() = nil, want a timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("waitReady blocked for %v, want ~50ms", elapsed)
+	}
+}
+
+func TestWaitReadySucceeds(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	defer r.Close()
+	go func() {
+		w.Write([]byte{1})
+		w.Close()
+	}()
+
+	if err := waitReady(r, time.Second); err != nil {
+		t.Fatalf("waitReady() = %v, want nil", err)
+	}
+}
+
+func TestInheritedListenerFD(t *testing.T) {
+	srv := New(TCPHandler(func(ctx context.Context, con net.Conn) error { return nil }))
+	if _, ok := inheritedListenerFD(srv); ok {
+		t.Fatalf("inheritedListenerFD() ok = true without GracefulRestart")
+	}
+
+	srv = New(GracefulRestart(true), TCPHandler(func(ctx context.Context, con net.Conn) error { return nil }))
+	os.Setenv(ListenerFDEnv, "7")
+	defer os.Unsetenv(ListenerFDEnv)
+
+	fd, ok := inheritedListenerFD(srv)
+	if !ok || fd != 7 {
+		t.Fatalf("inheritedListenerFD() = (%d, %v), want (7, true)", fd, ok)
+	}
+}
+
+func TestRestartWithoutGracefulRestartErrors(t *testing.T) {
+	srv := New(
+		Address("127.0.0.1:0"),
+		TCPHandler(func(ctx context.Context, con net.Conn) error { return nil }),
+	)
+	if err := srv.Open(); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer srv.listener.Close()
+
+	if err := srv.Restart(0, 0); err == nil {
+		t.Fatalf("Restart() = nil, want an error since GracefulRestart is disabled")
+	}
+}
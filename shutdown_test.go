@@ -0,0 +1,39 @@
+package tcpserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestShutdownForceClosesBlockedConn(t *testing.T) {
+	srv := New(
+		Address("127.0.0.1:0"),
+		TCPHandler(func(ctx context.Context, con net.Conn) error {
+			buf := make([]byte, 1)
+			_, err := con.Read(buf) // blocks until Shutdown force-closes con
+			return err
+		}),
+	)
+	go srv.Serve(context.Background())
+	<-srv.Serving()
+
+	conn, err := net.Dial(srv.network, srv.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond) // let the handler goroutine start reading
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown() = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Shutdown took %v, want it to return promptly once the deadline fires", elapsed)
+	}
+	<-srv.Stopped()
+}
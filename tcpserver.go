@@ -3,13 +3,17 @@ package tcpserver
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
+	"os"
 	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/x-mod/event"
-	"github.com/x-mod/glog"
 	"golang.org/x/net/trace"
 )
 
@@ -32,6 +36,36 @@ type Server struct {
 	serving   *event.Event
 	wgroup    sync.WaitGroup
 	ctxCancel context.CancelFunc
+	maxConns  int
+	connSem   chan struct{}
+	connsMu   sync.Mutex
+	conns     map[net.Conn]struct{}
+
+	unlinkExisting bool
+	socketMode     os.FileMode
+
+	onAccept       func(net.Conn)
+	onClose        func(net.Conn, error)
+	onAcceptError  func(error)
+	onHandlerError func(net.Conn, error)
+
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	idleTimeout  time.Duration
+
+	gracefulRestart bool
+
+	logger  Logger
+	connSeq uint64
+
+	tlsCertPath       string
+	tlsKeyPath        string
+	tlsReloadInterval time.Duration
+	certStore         atomic.Pointer[tls.Certificate]
+
+	rawListener net.Listener
+	boundSocket bool
+	restarting  atomic.Bool
 }
 
 // Name option for tcpserver
@@ -90,6 +124,81 @@ func NetTrace(flag bool) ServerOpt {
 	}
 }
 
+// UnlinkExisting option removes a stale unix domain socket file at
+// Address before binding, so a server that crashed without cleaning up
+// can be restarted without an "address already in use" error.
+func UnlinkExisting(flag bool) ServerOpt {
+	return func(srv *Server) {
+		srv.unlinkExisting = flag
+	}
+}
+
+// SocketMode option sets the file mode of a unix domain socket once it
+// has been created, e.g. to make it group-writable for a co-located
+// sidecar.
+func SocketMode(mode os.FileMode) ServerOpt {
+	return func(srv *Server) {
+		srv.socketMode = mode
+	}
+}
+
+// OnAccept option registers a callback invoked right after a connection is accepted
+func OnAccept(fn func(net.Conn)) ServerOpt {
+	return func(srv *Server) {
+		srv.onAccept = fn
+	}
+}
+
+// OnClose option registers a callback invoked once a connection's handler has returned
+func OnClose(fn func(net.Conn, error)) ServerOpt {
+	return func(srv *Server) {
+		srv.onClose = fn
+	}
+}
+
+// OnAcceptError option registers a callback invoked whenever Accept fails,
+// except when the listener has been closed (net.ErrClosed)
+func OnAcceptError(fn func(error)) ServerOpt {
+	return func(srv *Server) {
+		srv.onAcceptError = fn
+	}
+}
+
+// OnHandlerError option registers a callback invoked when the Handler returns an error
+func OnHandlerError(fn func(net.Conn, error)) ServerOpt {
+	return func(srv *Server) {
+		srv.onHandlerError = fn
+	}
+}
+
+// ReadTimeout option for per-connection read deadline
+func ReadTimeout(d time.Duration) ServerOpt {
+	return func(srv *Server) {
+		srv.readTimeout = d
+	}
+}
+
+// WriteTimeout option for per-connection write deadline
+func WriteTimeout(d time.Duration) ServerOpt {
+	return func(srv *Server) {
+		srv.writeTimeout = d
+	}
+}
+
+// IdleTimeout option for per-connection overall deadline
+func IdleTimeout(d time.Duration) ServerOpt {
+	return func(srv *Server) {
+		srv.idleTimeout = d
+	}
+}
+
+// MaxConnections option caps the number of concurrently handled conns; n <= 0 means unlimited.
+func MaxConnections(n int) ServerOpt {
+	return func(srv *Server) {
+		srv.maxConns = n
+	}
+}
+
 // ServerOpt typedef
 type ServerOpt func(*Server)
 
@@ -101,6 +210,8 @@ func New(opts ...ServerOpt) *Server {
 		openned: event.New(),
 		serving: event.New(),
 		stopped: event.New(),
+		conns:   make(map[net.Conn]struct{}),
+		logger:  defaultLogger(),
 	}
 	for _, opt := range opts {
 		opt(serv)
@@ -109,25 +220,48 @@ func New(opts ...ServerOpt) *Server {
 		_, file, line, _ := runtime.Caller(1)
 		serv.events = trace.NewEventLog(serv.name, fmt.Sprintf("%s:%d", file, line))
 	}
+	if serv.maxConns > 0 {
+		serv.connSem = make(chan struct{}, serv.maxConns)
+	}
 	return serv
 }
 
-func (srv *Server) printf(format string, a ...interface{}) {
+// logInfo logs msg at info level through srv.logger, and mirrors it to
+// the NetTrace event log if tracing is enabled.
+func (srv *Server) logInfo(msg string, kv ...interface{}) {
 	srv.mu.Lock()
 	defer srv.mu.Unlock()
 	if srv.events != nil {
-		srv.events.Printf(format, a...)
+		srv.events.Printf("%s %v", msg, kv)
 	}
-	glog.V(2).Infof(format, a...)
+	srv.logger.Info(msg, kv...)
 }
 
-func (srv *Server) errorf(format string, a ...interface{}) {
+// logError logs msg at error level through srv.logger, and mirrors it
+// to the NetTrace event log if tracing is enabled.
+func (srv *Server) logError(msg string, kv ...interface{}) {
 	srv.mu.Lock()
 	defer srv.mu.Unlock()
 	if srv.events != nil {
-		srv.events.Errorf(format, a...)
+		srv.events.Errorf("%s %v", msg, kv)
+	}
+	srv.logger.Error(msg, kv...)
+}
+
+// isUnixNetwork reports whether network names a unix domain socket
+// listener kind.
+func isUnixNetwork(network string) bool {
+	switch network {
+	case "unix", "unixpacket":
+		return true
 	}
-	glog.Errorf(format, a...)
+	return false
+}
+
+// isAbstractSocket reports whether address names a Linux abstract
+// socket (no backing file, nothing to unlink/chmod).
+func isAbstractSocket(address string) bool {
+	return strings.HasPrefix(address, "@")
 }
 
 func (srv *Server) Open() error {
@@ -137,28 +271,72 @@ func (srv *Server) Open() error {
 	if srv.handler == nil {
 		return fmt.Errorf("tcpserver.Handler required")
 	}
+	if srv.network == "tcp" && (strings.HasPrefix(srv.address, "/") || isAbstractSocket(srv.address)) {
+		srv.network = "unix"
+	}
 	if srv.listener == nil {
-		ln, err := net.Listen(srv.network, srv.address)
-		if err != nil {
+		if fd, ok := inheritedListenerFD(srv); ok {
+			ln, err := net.FileListener(os.NewFile(uintptr(fd), fmt.Sprintf("%s:%s", srv.network, srv.address)))
+			if err != nil {
+				return err
+			}
+			srv.logInfo("inherited listener", "name", srv.name, "fd", fd, "network", srv.network, "address", srv.address)
+			srv.listener = ln
+			srv.boundSocket = true
+		} else {
+			if isUnixNetwork(srv.network) && srv.unlinkExisting && !isAbstractSocket(srv.address) {
+				if err := os.Remove(srv.address); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			}
+			ln, err := net.Listen(srv.network, srv.address)
+			if err != nil {
+				return err
+			}
+			srv.logInfo("serving", "name", srv.name, "network", srv.network, "address", srv.address)
+			srv.listener = ln
+			srv.boundSocket = true
+			if isUnixNetwork(srv.network) && srv.socketMode != 0 && !isAbstractSocket(srv.address) {
+				if err := os.Chmod(srv.address, srv.socketMode); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	srv.rawListener = srv.listener
+	if srv.tlsCertPath != "" {
+		if err := srv.ReloadTLS(); err != nil {
 			return err
 		}
-		srv.printf("%s serving at %s:%s", srv.name, srv.network, srv.address)
-		srv.listener = ln
+		srv.startTLSReloader()
 	}
 	if srv.tls != nil {
 		srv.listener = tls.NewListener(srv.listener, srv.tls)
 	}
 
 	srv.openned.Fire()
+	srv.signalReady()
 	return nil
 }
 
+// closeListener closes the listener and, if boundSocket, unlinks the unix socket file;
+// the file is kept during a restart handoff, and abstract sockets have none.
+func (srv *Server) closeListener() {
+	if ul, ok := srv.rawListener.(*net.UnixListener); ok {
+		ul.SetUnlinkOnClose(false)
+	}
+	srv.listener.Close()
+	if srv.boundSocket && !srv.restarting.Load() && isUnixNetwork(srv.network) && !isAbstractSocket(srv.address) {
+		os.Remove(srv.address)
+	}
+}
+
 // Serve tcpserver serving
 func (srv *Server) Serve(ctx context.Context) error {
 	if err := srv.Open(); err != nil {
 		return err
 	}
-	defer srv.listener.Close()
+	defer srv.closeListener()
 	//flags
 	defer srv.stopped.Fire()
 	srv.serving.Fire()
@@ -166,37 +344,129 @@ func (srv *Server) Serve(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	srv.ctxCancel = cancel
 	for {
-		select {
-		case <-ctx.Done():
-			srv.errorf("%s stopped: %v", srv.name, ctx.Err())
-			return ctx.Err()
-		default:
-			con, err := srv.listener.Accept()
-			if err != nil {
-				if ne, ok := err.(net.Error); ok && ne.Temporary() {
-					srv.errorf("%s accept temp err: %v", srv.name, ne)
-					continue
-				}
-				srv.errorf("%s accept failed: %v", srv.name, err)
+		if srv.connSem != nil {
+			select {
+			case <-ctx.Done():
+				srv.logError("stopped", "name", srv.name, "err", ctx.Err())
+				return ctx.Err()
+			case srv.connSem <- struct{}{}:
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				srv.logError("stopped", "name", srv.name, "err", ctx.Err())
+				return ctx.Err()
+			default:
+			}
+		}
+
+		con, err := srv.listener.Accept()
+		if err != nil {
+			if srv.connSem != nil {
+				<-srv.connSem
+			}
+			if errors.Is(err, net.ErrClosed) {
+				// Our own Shutdown/Close closed the listener; not a real error.
 				return err
 			}
+			if srv.onAcceptError != nil {
+				srv.onAcceptError(err)
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				srv.logError("accept temp error", "name", srv.name, "err", ne)
+				continue
+			}
+			srv.logError("accept failed", "name", srv.name, "err", err)
+			return err
+		}
+		srv.trackConn(con)
 
-			srv.wgroup.Add(1)
-			go func() {
-				defer srv.wgroup.Done()
-				if srv.traced {
-					tr := trace.New("client", con.RemoteAddr().String())
-					ctx = trace.NewContext(ctx, tr)
-				}
-				if err := srv.handler(ctx, con); err != nil {
-					srv.errorf("client (%s) failed: %v", con.RemoteAddr().String(), err)
-				}
-				if tr, ok := trace.FromContext(ctx); ok {
-					tr.Finish()
+		srv.wgroup.Add(1)
+		go func() {
+			defer srv.wgroup.Done()
+			defer srv.untrackConn(con)
+			if srv.connSem != nil {
+				defer func() { <-srv.connSem }()
+			}
+			if srv.onAccept != nil {
+				srv.onAccept(con)
+			}
+			srv.applyConnDeadlines(con)
+			if srv.traced {
+				tr := trace.New("client", con.RemoteAddr().String())
+				ctx = trace.NewContext(ctx, tr)
+			}
+			connID := atomic.AddUint64(&srv.connSeq, 1)
+			start := time.Now()
+			err := srv.handler(ctx, con)
+			if err != nil {
+				srv.logError("client failed",
+					"remote_addr", con.RemoteAddr().String(),
+					"conn_id", connID,
+					"handler_duration_ms", time.Since(start).Milliseconds(),
+					"err", err,
+				)
+				if srv.onHandlerError != nil {
+					srv.onHandlerError(con, err)
 				}
-			}()
+			}
+			if srv.onClose != nil {
+				srv.onClose(con, err)
+			}
+			if tr, ok := trace.FromContext(ctx); ok {
+				tr.Finish()
+			}
+		}()
+	}
+}
+
+// applyConnDeadlines sets con's read and write deadlines from
+// ReadTimeout/WriteTimeout/IdleTimeout, folding IdleTimeout in as an
+// upper bound rather than overwriting the other two via SetDeadline.
+func (srv *Server) applyConnDeadlines(con net.Conn) {
+	now := time.Now()
+	var readDeadline, writeDeadline time.Time
+	if srv.readTimeout > 0 {
+		readDeadline = now.Add(srv.readTimeout)
+	}
+	if srv.writeTimeout > 0 {
+		writeDeadline = now.Add(srv.writeTimeout)
+	}
+	if srv.idleTimeout > 0 {
+		idleDeadline := now.Add(srv.idleTimeout)
+		if readDeadline.IsZero() || idleDeadline.Before(readDeadline) {
+			readDeadline = idleDeadline
+		}
+		if writeDeadline.IsZero() || idleDeadline.Before(writeDeadline) {
+			writeDeadline = idleDeadline
 		}
 	}
+	if !readDeadline.IsZero() {
+		con.SetReadDeadline(readDeadline)
+	}
+	if !writeDeadline.IsZero() {
+		con.SetWriteDeadline(writeDeadline)
+	}
+}
+
+func (srv *Server) trackConn(con net.Conn) {
+	srv.connsMu.Lock()
+	srv.conns[con] = struct{}{}
+	srv.connsMu.Unlock()
+}
+
+func (srv *Server) untrackConn(con net.Conn) {
+	srv.connsMu.Lock()
+	delete(srv.conns, con)
+	srv.connsMu.Unlock()
+}
+
+func (srv *Server) closeActiveConns() {
+	srv.connsMu.Lock()
+	defer srv.connsMu.Unlock()
+	for con := range srv.conns {
+		con.Close()
+	}
 }
 
 // Serving check
@@ -216,13 +486,43 @@ func (srv *Server) IsStopped() bool {
 	return srv.stopped.HasFired()
 }
 
-// Close tcpserver waiting all connections finished
-func (srv *Server) Close() <-chan struct{} {
+// Close tcpserver waiting up to timeout for in-flight connections finished, timeout <= 0 waits indefinitely;
+// once timeout elapses the remaining connections are closed and Close waits for their handlers to return
+func (srv *Server) Close(timeout time.Duration) <-chan struct{} {
 	if srv.serving.HasFired() {
-		srv.listener.Close()
-		srv.ctxCancel()
-		srv.wgroup.Wait()
+		ctx := context.Background()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		srv.Shutdown(ctx)
 		return srv.stopped.Done()
 	}
 	return event.Done()
 }
+
+// Shutdown stops accepting new connections and waits for in-flight handlers until ctx is done;
+// it then closes the remaining connections, waits for their handlers to return and returns ctx.Err()
+func (srv *Server) Shutdown(ctx context.Context) error {
+	if !srv.serving.HasFired() {
+		return nil
+	}
+	srv.closeListener()
+	srv.ctxCancel()
+
+	done := make(chan struct{})
+	go func() {
+		srv.wgroup.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		srv.closeActiveConns()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,68 @@
+package tcpserver
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+)
+
+// TLSCertFiles option for a hot-reloadable certificate/key pair
+func TLSCertFiles(certPath, keyPath string) ServerOpt {
+	return func(srv *Server) {
+		srv.tlsCertPath = certPath
+		srv.tlsKeyPath = keyPath
+		if srv.tls == nil {
+			srv.tls = &tls.Config{}
+		}
+		cfg := srv.tls
+		cfg.GetCertificate = func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert := srv.certStore.Load()
+			if cert == nil {
+				return nil, fmt.Errorf("tcpserver: no TLS certificate loaded")
+			}
+			return cert, nil
+		}
+	}
+}
+
+// TLSReloadInterval option for how often to reload the TLSCertFiles certificate
+func TLSReloadInterval(d time.Duration) ServerOpt {
+	return func(srv *Server) {
+		srv.tlsReloadInterval = d
+	}
+}
+
+// ReloadTLS reloads the TLSCertFiles certificate into the live TLS config
+func (srv *Server) ReloadTLS() error {
+	if srv.tlsCertPath == "" || srv.tlsKeyPath == "" {
+		return fmt.Errorf("tcpserver: TLSCertFiles not configured")
+	}
+	cert, err := tls.LoadX509KeyPair(srv.tlsCertPath, srv.tlsKeyPath)
+	if err != nil {
+		return err
+	}
+	srv.certStore.Store(&cert)
+	srv.logInfo("tls certificate reloaded", "name", srv.name, "cert", srv.tlsCertPath)
+	return nil
+}
+
+// startTLSReloader runs ReloadTLS on TLSReloadInterval until the server stops
+func (srv *Server) startTLSReloader() {
+	if srv.tlsReloadInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(srv.tlsReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-srv.stopped.Done():
+				return
+			case <-ticker.C:
+				if err := srv.ReloadTLS(); err != nil {
+					srv.logError("tls reload failed", "name", srv.name, "err", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,44 @@
+package tcpserver
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func echoOnce(ctx context.Context, con net.Conn) error {
+	return con.Close()
+}
+
+func TestUnixSocketUnlinkedOnClose(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(Address(sockPath), TCPHandler(echoOnce))
+	go srv.Serve(context.Background())
+	<-srv.Serving()
+
+	if _, err := os.Stat(sockPath); err != nil {
+		t.Fatalf("expected socket file to exist after Serve: %v", err)
+	}
+
+	<-srv.Close(0)
+
+	if _, err := os.Stat(sockPath); !os.IsNotExist(err) {
+		t.Fatalf("expected socket file to be removed after Close, stat err = %v", err)
+	}
+}
+
+func TestUnixSocketKeptDuringRestartHandoff(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(Address(sockPath), TCPHandler(echoOnce))
+	go srv.Serve(context.Background())
+	<-srv.Serving()
+
+	srv.restarting.Store(true)
+	<-srv.Close(0)
+
+	if _, err := os.Stat(sockPath); err != nil {
+		t.Fatalf("expected socket file to survive a restart handoff, stat err = %v", err)
+	}
+}